@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Bot fans the events of multiple Messenger backends into one merged
+// channel so the dispatch loop stays the same no matter how many backends
+// are running.
+type Bot struct {
+	messengers []servoapi.Messenger
+	events     chan servoapi.InboundMessage
+	errors     chan error
+}
+
+func newBot() *Bot {
+	return &Bot{
+		events: make(chan servoapi.InboundMessage),
+		errors: make(chan error),
+	}
+}
+
+// AddMessenger connects m and forwards its events into the Bot's merged
+// Events channel, tagging each one with its origin so replies are routed
+// back to the right backend. It also forwards m's fatal errors into the
+// Bot's merged Errors channel, so e.g. invalid credentials on one backend
+// still surface to main instead of leaving it silently dead.
+func (b *Bot) AddMessenger(m servoapi.Messenger) error {
+	if err := m.Connect(); err != nil {
+		return fmt.Errorf("connecting messenger %q: %s", m.Name(), err.Error())
+	}
+	b.messengers = append(b.messengers, m)
+
+	go func() {
+		for in := range m.Events() {
+			in.Via = m
+			b.events <- in
+		}
+	}()
+	go func() {
+		for err := range m.Errors() {
+			b.errors <- fmt.Errorf("messenger %q: %s", m.Name(), err.Error())
+		}
+	}()
+	return nil
+}
+
+// Events returns the merged stream of inbound messages across every
+// registered backend.
+func (b *Bot) Events() <-chan servoapi.InboundMessage {
+	return b.events
+}
+
+// Errors returns the merged stream of fatal backend errors across every
+// registered backend.
+func (b *Bot) Errors() <-chan error {
+	return b.errors
+}
+
+// Get returns the registered messenger with the given Name(), or nil if
+// none is connected.
+func (b *Bot) Get(name string) servoapi.Messenger {
+	for _, m := range b.messengers {
+		if m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Close tears down every registered backend.
+func (b *Bot) Close() {
+	for _, m := range b.messengers {
+		if err := m.Close(); err != nil {
+			fmt.Printf("Error: closing messenger %q: %s\n", m.Name(), err.Error())
+		}
+	}
+}
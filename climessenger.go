@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CLIMessenger is a Messenger that reads commands from stdin and prints
+// replies to stdout, so the bot can be exercised locally without Slack or
+// a Pi.
+type CLIMessenger struct {
+	events chan servoapi.InboundMessage
+}
+
+func newCLIMessenger() *CLIMessenger {
+	return &CLIMessenger{events: make(chan servoapi.InboundMessage)}
+}
+
+func (m *CLIMessenger) Name() string { return "cli" }
+
+func (m *CLIMessenger) Connect() error {
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			m.events <- servoapi.InboundMessage{
+				Text:    text,
+				User:    "local",
+				Channel: "cli",
+			}
+		}
+		close(m.events)
+	}()
+	return nil
+}
+
+func (m *CLIMessenger) Events() <-chan servoapi.InboundMessage {
+	return m.events
+}
+
+// Errors never fires; stdin has no equivalent of bad credentials.
+func (m *CLIMessenger) Errors() <-chan error {
+	return nil
+}
+
+func (m *CLIMessenger) Reply(in servoapi.InboundMessage, text string) error {
+	fmt.Println(text)
+	return nil
+}
+
+func (m *CLIMessenger) Close() error {
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// HTTPAPI exposes the servo over plain HTTP/REST and a streaming WebSocket,
+// sharing the same servo, PluginManager, and MotionScheduler the Slack loop
+// drives, so a curl request and a Slack message move the same servo the same
+// way.
+type HTTPAPI struct {
+	addr  string
+	token string
+	servo *servoapi.Servo
+	pm    *PluginManager
+	srv   *http.Server
+
+	upgrader websocket.Upgrader
+}
+
+func newHTTPAPI(addr, token string, s *servoapi.Servo, pm *PluginManager) *HTTPAPI {
+	return &HTTPAPI{
+		addr:  addr,
+		token: token,
+		servo: s,
+		pm:    pm,
+	}
+}
+
+// Start begins serving in the background. It does not block.
+func (h *HTTPAPI) Start() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/angle", h.handleAngle)
+	mux.HandleFunc("/command", h.authenticated(h.handleCommand))
+	mux.HandleFunc("/ws", h.handleWS)
+
+	h.srv = &http.Server{Addr: h.addr, Handler: mux}
+
+	go func() {
+		if err := h.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error: HTTP API stopped: %s\n", err.Error())
+		}
+	}()
+	return nil
+}
+
+// Close shuts down the HTTP server.
+func (h *HTTPAPI) Close() error {
+	if h.srv == nil {
+		return nil
+	}
+	return h.srv.Close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// authenticated wraps fn, rejecting requests missing a valid
+// "Authorization: Bearer <SERVO_HTTP_TOKEN>" header. If no token is
+// configured, every request is let through.
+func (h *HTTPAPI) authenticated(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" && r.Header.Get("Authorization") != "Bearer "+h.token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+type angleRequest struct {
+	Angle      float32 `json:"angle"`
+	DurationMs int     `json:"duration_ms"`
+}
+
+type angleResponse struct {
+	Angle float32 `json:"angle"`
+}
+
+// handleAngle serves GET /angle (current angle) and, once authenticated,
+// POST /angle (move to a target angle over an optional duration).
+func (h *HTTPAPI) handleAngle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, angleResponse{Angle: h.servo.Angle()})
+
+	case http.MethodPost:
+		h.authenticated(h.handleSetAngle)(w, r)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *HTTPAPI) handleSetAngle(w http.ResponseWriter, r *http.Request) {
+	var req angleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("bad request: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	duration := time.Duration(req.DurationMs) * time.Millisecond
+	h.servo.Motion.QueueSweep(req.Angle, duration, h.servo.Angle())
+
+	writeJSON(w, angleResponse{Angle: req.Angle})
+}
+
+type commandRequest struct {
+	Text string `json:"text"`
+}
+
+type commandResponse struct {
+	Matched bool   `json:"matched"`
+	Reply   string `json:"reply"`
+}
+
+// handleCommand routes a free-form command string through the same
+// PluginManager.Dispatch path a Slack message takes, so `{"text":"turn
+// left"}` behaves identically to typing "turn left" in Slack.
+func (h *HTTPAPI) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req commandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("bad request: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	m := &httpReplyMessenger{}
+	in := servoapi.InboundMessage{Text: req.Text, User: "http", Channel: "http"}
+	matched := h.pm.Dispatch(m, in, h.servo)
+
+	writeJSON(w, commandResponse{Matched: matched, Reply: m.text})
+}
+
+// handleWS upgrades to a WebSocket and streams {"angle":N} at tick
+// resolution for as long as the client stays connected, for live
+// dashboards.
+func (h *HTTPAPI) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		fmt.Printf("Error: upgrading websocket: %s\n", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	ticker := time.NewTicker(cInterpDuration)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := conn.WriteJSON(angleResponse{Angle: h.servo.Angle()}); err != nil {
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// httpReplyMessenger captures the text of a single reply so /command can
+// return it synchronously; it is not connected to Bot and does not
+// participate in its multi-backend fan-in.
+type httpReplyMessenger struct {
+	text string
+}
+
+func (m *httpReplyMessenger) Name() string                          { return "http" }
+func (m *httpReplyMessenger) Connect() error                        { return nil }
+func (m *httpReplyMessenger) Events() <-chan servoapi.InboundMessage { return nil }
+func (m *httpReplyMessenger) Errors() <-chan error                   { return nil }
+func (m *httpReplyMessenger) Close() error                           { return nil }
+
+func (m *httpReplyMessenger) Reply(in servoapi.InboundMessage, text string) error {
+	m.text = text
+	return nil
+}
@@ -5,210 +5,123 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
-	"regexp"
-	"strings"
 	"time"
 
-	"github.com/nlopes/slack"
 	rpio "github.com/sabhiram/go-rpio"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
 )
 
 const (
-	cFreqMultiplier     = 200 // 50hz but in 200 increments to get 10
-	cCenterAngleDegrees = 90.0
-	cAngleDelta         = 180.0 / (20.0 - 10.0)
-	cInterpDuration     = 200 * time.Millisecond
-	cHelpMessage        = "I am a servo control bot! You can tell me to `turn left`,`turn right`, `center`, or ask me for my current `angle`. You can even say things like `full left` or `full right`."
-)
+	// cInterpDuration is how often the motion scheduler is sampled; it
+	// bounds how smooth in-flight motions look.
+	cInterpDuration = 20 * time.Millisecond
 
-////////////////////////////////////////////////////////////////////////////////
+	// cPluginDirEnvVar names the env var pointing at a directory of `.so`
+	// plugins to load at startup. Unset or empty disables plugin loading.
+	cPluginDirEnvVar = "SERVO_PLUGIN_DIR"
 
-func fatalOnErr(err error) {
-	if err != nil {
-		fmt.Printf("Fatal error: %s\n", err.Error())
+	// cDBPathEnvVar names the env var pointing at the BoltDB file used to
+	// persist servo state and the command audit log.
+	cDBPathEnvVar = "SERVO_DB_PATH"
 
-		os.Exit(1)
-	}
-}
+	// cTelegramTokenEnvVar, if set, starts a TelegramMessenger alongside
+	// the (always-on) Slack one.
+	cTelegramTokenEnvVar = "SERVO_TELEGRAM_TOKEN"
 
-func clampAngle(angle float32) float32 {
-	if angle < 0.0 {
-		angle = 0.0
-	} else if angle > 180.0 {
-		angle = 180.0
-	}
-	return angle
-}
+	// cCLIEnvVar, if set to any non-empty value, starts a CLIMessenger so
+	// the bot can be driven from stdin without a Pi or Slack.
+	cCLIEnvVar = "SERVO_CLI"
 
-////////////////////////////////////////////////////////////////////////////////
+	// cStatusPageURLEnvVar, if set, is linked from the `angle` command's
+	// rich reply title.
+	cStatusPageURLEnvVar = "SERVO_STATUS_PAGE_URL"
+
+	// cHTTPAddrEnvVar names the env var giving the `host:port` the HTTP/
+	// WebSocket control API listens on. Unset or empty disables it.
+	cHTTPAddrEnvVar = "SERVO_HTTP_ADDR"
 
-type cmdFunc func(rtm *slack.RTM, ev *slack.MessageEvent) error
+	// cHTTPTokenEnvVar, if set, is the bearer token required on the HTTP
+	// API's write endpoints.
+	cHTTPTokenEnvVar = "SERVO_HTTP_TOKEN"
+)
 
 ////////////////////////////////////////////////////////////////////////////////
 
-type servo struct {
-	pin    rpio.Pin
-	angle  float32
-	target float32
-}
+func fatalOnErr(err error) {
+	if err != nil {
+		fmt.Printf("Fatal error: %s\n", err.Error())
 
-func newServo(bcmpid uint8) (*servo, error) {
-	p := rpio.Pin(bcmpid)
-	p.Mode(rpio.Pwm)
-	p.Freq(50 * cFreqMultiplier)
-	s := &servo{
-		pin:    p,
-		angle:  cCenterAngleDegrees,
-		target: cCenterAngleDegrees,
+		os.Exit(1)
 	}
-	s.setAngle(cCenterAngleDegrees)
-	return s, nil
-}
-
-func (s *servo) setTarget(tangle float32) error {
-	s.target = clampAngle(tangle)
-	return nil
 }
 
-// setAngle sets the servo angle to between 0 and 180 degrees.
-func (s *servo) setAngle(angle float32) error {
-	angle = clampAngle(angle)
-
-	// DutyCycle of 1.0ms / 20ms corresponds to 0 deg
-	// 				1.5ms / 20ms corresponds to 90 deg
-	//				2.0ms / 20ms corresponds to 180 deg
-	dc := uint32(((1.0 + (angle / 180.0)) / 20.0) * cFreqMultiplier)
-	s.pin.DutyCycle(dc, cFreqMultiplier)
-	s.angle = angle
-	return nil
-}
-
-func (s *servo) reply(msg string, rtm *slack.RTM, ev *slack.MessageEvent) error {
-	rtm.SendMessage(rtm.NewOutgoingMessage(msg, ev.Channel))
-	return nil
-}
-
-func (s *servo) randomReply(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	replies := []string{
-		"Umm ok, I can do that for you!",
-		"You must be management, snooping around.",
-		"Looking for waldo? Let me see what I can do.",
-		"Getting right on that boss!",
-	}
-	return s.reply(replies[rand.Intn(len(replies))], rtm, ev)
-}
+////////////////////////////////////////////////////////////////////////////////
 
-func (s *servo) errorReply(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	replies := []string{
-		"Not sure I know what you mean. Type `help` and such.",
-		"You must be looking for the `help`?",
-		"Are you sure that is a valid command?",
+func main() {
+	slackToken := os.Getenv("SLACKBOT_TOKEN")
+	if slackToken == "" {
+		fatalOnErr(errors.New(`"SLACKBOT_TOKEN" env value missing`))
 	}
-	return s.reply(replies[rand.Intn(len(replies))], rtm, ev)
-}
 
-func (s *servo) turnLeft(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	if err := s.setAngle(s.angle - cAngleDelta); err != nil {
-		return err
+	// store is nil, disabling persistence, audit logging, history/stats, and
+	// schedule restoration, unless SERVO_DB_PATH names a BoltDB file to use.
+	var store *Store
+	var angleStore servoapi.AngleStore
+	if dbPath := os.Getenv(cDBPathEnvVar); dbPath != "" {
+		var err error
+		store, err = openStore(dbPath)
+		fatalOnErr(err)
+		defer store.Close()
+		angleStore = store
 	}
-	return s.randomReply(rtm, ev)
-}
 
-func (s *servo) turnRight(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	if err := s.setAngle(s.angle + cAngleDelta); err != nil {
-		return err
-	}
-	return s.randomReply(rtm, ev)
-}
+	fatalOnErr(rpio.Open())
+	defer rpio.Close()
 
-func (s *servo) goto0(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	if err := s.setTarget(0.0); err != nil {
-		return err
-	}
-	return s.randomReply(rtm, ev)
-}
+	servo, err := servoapi.NewServo(19, angleStore, os.Getenv(cStatusPageURLEnvVar))
+	fatalOnErr(err)
 
-func (s *servo) gotoCenter(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	if err := s.setTarget(cCenterAngleDegrees); err != nil {
-		return err
-	}
-	return s.randomReply(rtm, ev)
-}
+	pm := newPluginManager()
+	pm.store = store
+	pm.Register(newServoPlugin(pm))
 
-func (s *servo) goto180(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	if err := s.setTarget(180.0); err != nil {
-		return err
+	bot := newBot()
+	fatalOnErr(bot.AddMessenger(newSlackMessenger(slackToken)))
+	if telegramToken := os.Getenv(cTelegramTokenEnvVar); telegramToken != "" {
+		fatalOnErr(bot.AddMessenger(newTelegramMessenger(telegramToken)))
 	}
-	return s.randomReply(rtm, ev)
-}
-
-func (s *servo) getAngle(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	s.reply(fmt.Sprintf("Current angle: % .2f°", s.angle), rtm, ev)
-	return nil
-}
-
-func (s *servo) sendHelp(rtm *slack.RTM, ev *slack.MessageEvent) error {
-	return s.reply(cHelpMessage, rtm, ev)
-	return nil
-}
-
-////////////////////////////////////////////////////////////////////////////////
-
-func main() {
-	token := os.Getenv("SLACKBOT_TOKEN")
-	if token == "" {
-		fatalOnErr(errors.New(`"SLACKBOT_TOKEN" env value missing`))
+	if os.Getenv(cCLIEnvVar) != "" {
+		fatalOnErr(bot.AddMessenger(newCLIMessenger()))
 	}
+	defer bot.Close()
 
-	fatalOnErr(rpio.Open())
-	defer rpio.Close()
+	scheduler := newScheduler(store, pm, servo, bot)
+	fatalOnErr(scheduler.Start())
+	pm.Register(newSchedulerPlugin(scheduler))
 
-	servo, err := newServo(19)
-	fatalOnErr(err)
+	fatalOnErr(pm.LoadDir(os.Getenv(cPluginDirEnvVar)))
 
-	commands := map[string]cmdFunc{
-		"turn left":  servo.turnLeft,
-		"turn right": servo.turnRight,
-		"full left":  servo.goto0,
-		"center":     servo.gotoCenter,
-		"full right": servo.goto180,
-		"angle":      servo.getAngle,
-		"help":       servo.sendHelp,
+	if httpAddr := os.Getenv(cHTTPAddrEnvVar); httpAddr != "" {
+		api := newHTTPAPI(httpAddr, os.Getenv(cHTTPTokenEnvVar), servo, pm)
+		fatalOnErr(api.Start())
+		defer api.Close()
 	}
 
-	api := slack.New(token)
-	rtm := api.NewRTM()
-	go rtm.ManageConnection()
 	ticker := time.NewTicker(cInterpDuration)
 
-Loop:
 	for {
 		select {
-		case msg := <-rtm.IncomingEvents:
-			switch evtt := msg.Data.(type) {
-			case *slack.MessageEvent:
-				text := strings.TrimSpace(strings.ToLower(evtt.Text))
-				match := false
-				for k, fn := range commands {
-					if matched, _ := regexp.MatchString(k, text); matched {
-						fn(rtm, evtt)
-						match = true
-					}
-				}
-				if !match {
-					servo.errorReply(rtm, evtt)
-				}
-			case *slack.RTMError:
-				fmt.Printf("Error: %s\n", evtt.Error())
-			case *slack.InvalidAuthEvent:
-				fmt.Printf("Bad credentials\n")
-				break Loop
+		case in := <-bot.Events():
+			if !pm.Dispatch(in.Via, in, servo) {
+				servo.ErrorReply(in.Via, in)
+				servoapi.React(in.Via, in, false)
 			}
+		case err := <-bot.Errors():
+			fmt.Printf("Error: %s\n", err.Error())
 		case <-ticker.C:
-			if servo.target > servo.angle {
-				servo.setAngle(servo.angle + cAngleDelta)
-			} else if servo.target < servo.angle {
-				servo.setAngle(servo.angle - cAngleDelta)
+			if err := servo.Motion.Tick(servo); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
 			}
 		}
 	}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"plugin"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+// cHistoryLimit bounds how many audit entries the `history` command shows.
+const cHistoryLimit = 10
+
+////////////////////////////////////////////////////////////////////////////////
+
+// pluginSymbolName is the exported symbol every `.so` plugin must provide. It
+// must be a package-level variable implementing the servoapi.Plugin
+// interface, e.g. `var Plugin myWeatherPlugin`.
+const pluginSymbolName = "Plugin"
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PluginManager owns the set of registered plugins and dispatches incoming
+// messages to whichever plugins' patterns match.
+type PluginManager struct {
+	plugins []servoapi.Plugin
+	store   *Store // nil disables audit logging, `history`, and `stats`
+}
+
+func newPluginManager() *PluginManager {
+	return &PluginManager{}
+}
+
+// Register adds a plugin to the manager. Plugins are matched in registration
+// order, and more than one may handle the same message.
+func (pm *PluginManager) Register(p servoapi.Plugin) {
+	pm.plugins = append(pm.plugins, p)
+}
+
+// LoadDir loads every `.so` file in dir as a servoapi.Plugin and registers
+// it. It is not an error for dir to be empty or not exist; this just means
+// no additional plugins are loaded.
+//
+// A `.so` built out-of-tree declares its Plugin against the servoapi
+// package (github.com/sabhiram/slackbot-servo/servoapi), never against this
+// package main, since Go's plugin loader cannot import a main package from
+// anywhere but its own binary.
+func (pm *PluginManager) LoadDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".so" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		p, err := plugin.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening plugin %q: %s", path, err.Error())
+		}
+
+		sym, err := p.Lookup(pluginSymbolName)
+		if err != nil {
+			return fmt.Errorf("plugin %q missing %q symbol: %s", path, pluginSymbolName, err.Error())
+		}
+
+		loaded, ok := sym.(servoapi.Plugin)
+		if !ok {
+			return fmt.Errorf("plugin %q's %q symbol does not implement servoapi.Plugin", path, pluginSymbolName)
+		}
+
+		pm.Register(loaded)
+		fmt.Printf("Loaded plugin %q from %s\n", loaded.Name(), path)
+	}
+
+	return nil
+}
+
+// StartsMotion reports whether text would queue a motion on any registered
+// plugin that implements servoapi.MotionClassifier. Like Dispatch, it checks
+// each `;`-separated segment of text on its own.
+func (pm *PluginManager) StartsMotion(text string) bool {
+	for _, seg := range strings.Split(strings.ToLower(text), ";") {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+		for _, p := range pm.plugins {
+			if mc, ok := p.(servoapi.MotionClassifier); ok && mc.StartsMotion(seg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Dispatch routes in to every registered plugin whose pattern matches it,
+// and reports whether any plugin claimed the message. in.Text may contain
+// several `;`-separated commands, so a pattern claims the message if it
+// matches any one of its segments (the whole text, when it holds no `;`),
+// matching how Handle itself processes chained commands.
+func (pm *PluginManager) Dispatch(m servoapi.Messenger, in servoapi.InboundMessage, s *servoapi.Servo) bool {
+	text := strings.TrimSpace(strings.ToLower(in.Text))
+	segments := strings.Split(text, ";")
+	matched := false
+
+	for _, p := range pm.plugins {
+		for _, re := range p.Patterns() {
+			if matchesAny(re, segments) {
+				err := p.Handle(m, in, s)
+				if err != nil {
+					fmt.Printf("Error: plugin %q failed to handle %q: %s\n", p.Name(), text, err.Error())
+				}
+				servoapi.React(m, in, err == nil)
+				matched = true
+				pm.audit(in, re, s)
+				break
+			}
+		}
+	}
+
+	return matched
+}
+
+// matchesAny reports whether re matches any of segments, each trimmed of
+// surrounding whitespace.
+func matchesAny(re *regexp.Regexp, segments []string) bool {
+	for _, seg := range segments {
+		if re.MatchString(strings.TrimSpace(seg)) {
+			return true
+		}
+	}
+	return false
+}
+
+// audit records a matched command to the audit log, if persistence is
+// enabled.
+func (pm *PluginManager) audit(in servoapi.InboundMessage, re *regexp.Regexp, s *servoapi.Servo) {
+	if pm.store == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:    time.Now(),
+		User:    in.User,
+		Channel: in.Channel,
+		Pattern: re.String(),
+		Angle:   s.Angle(),
+	}
+	if err := pm.store.LogAction(entry); err != nil {
+		fmt.Printf("Error: failed to log audit entry: %s\n", err.Error())
+	}
+}
+
+// HelpText renders the `help` reply by concatenating every registered
+// plugin's own Help() text.
+func (pm *PluginManager) HelpText() string {
+	lines := make([]string, 0, len(pm.plugins))
+	for _, p := range pm.plugins {
+		lines = append(lines, fmt.Sprintf("*%s*: %s", p.Name(), p.Help()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// historyText renders the `history` reply: the last cHistoryLimit audit
+// entries, most recent first.
+func (pm *PluginManager) historyText() string {
+	if pm.store == nil {
+		return "No history available; persistence is disabled."
+	}
+
+	entries, err := pm.store.History(cHistoryLimit)
+	if err != nil {
+		return fmt.Sprintf("Couldn't load history: %s", err.Error())
+	}
+	if len(entries) == 0 {
+		return "No actions recorded yet."
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = fmt.Sprintf("%s: <@%s> in <#%s> matched `%s` -> % .2f°",
+			e.Time.Format(time.RFC3339), e.User, e.Channel, e.Pattern, e.Angle)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statsText renders the `stats` reply: per-user command counts, most
+// frequent first.
+func (pm *PluginManager) statsText() string {
+	if pm.store == nil {
+		return "No stats available; persistence is disabled."
+	}
+
+	counts, err := pm.store.Stats()
+	if err != nil {
+		return fmt.Sprintf("Couldn't load stats: %s", err.Error())
+	}
+	if len(counts) == 0 {
+		return "No commands recorded yet."
+	}
+
+	lines := make([]string, len(counts))
+	for i, c := range counts {
+		lines[i] = fmt.Sprintf("<@%s>: %d commands", c.User, c.Count)
+	}
+	return strings.Join(lines, "\n")
+}
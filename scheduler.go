@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+// errNoStore is returned by Scheduler methods that persist schedules when no
+// SERVO_DB_PATH was configured, since a schedule that can't survive a
+// restart isn't one worth registering.
+var errNoStore = errors.New("scheduling requires persistence; set SERVO_DB_PATH")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Scheduler runs recurring servo commands on cron expressions, persisting
+// them across restarts and dispatching each through the same
+// PluginManager.Dispatch path a Slack message would take so plugins see
+// identical semantics.
+type Scheduler struct {
+	cron  *cron.Cron
+	store *Store
+	pm    *PluginManager
+	servo *servoapi.Servo
+	bot   *Bot
+	ids   map[uint64]cron.EntryID
+}
+
+func newScheduler(store *Store, pm *PluginManager, s *servoapi.Servo, bot *Bot) *Scheduler {
+	return &Scheduler{
+		cron:  cron.New(),
+		store: store,
+		pm:    pm,
+		servo: s,
+		bot:   bot,
+		ids:   make(map[uint64]cron.EntryID),
+	}
+}
+
+// Start restores any schedules persisted from a previous run, if persistence
+// is enabled, and starts the cron loop. A persisted job that fails to
+// register (e.g. its cron expression no longer parses) is logged and
+// skipped rather than aborting startup, since main treats Start's error as
+// fatal and one bad row shouldn't take down every other schedule.
+func (sc *Scheduler) Start() error {
+	if sc.store != nil {
+		jobs, err := sc.store.ListSchedules()
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if err := sc.register(job); err != nil {
+				fmt.Printf("Error: schedule #%d (%q) could not be registered, skipping: %s\n", job.ID, job.Cron, err.Error())
+			}
+		}
+	}
+	sc.cron.Start()
+	return nil
+}
+
+// Add registers a new scheduled job with cron and persists it. The cron
+// expression is validated before anything is saved, so a typo never makes
+// it into the store where it would otherwise fail to register again on
+// every future restart. It fails if persistence is disabled, since a
+// schedule that can't survive a restart isn't one worth registering.
+func (sc *Scheduler) Add(expr, command string, m servoapi.Messenger, in servoapi.InboundMessage) (uint64, error) {
+	if sc.store == nil {
+		return 0, errNoStore
+	}
+
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return 0, fmt.Errorf("invalid cron expression %q: %s", expr, err.Error())
+	}
+
+	job := ScheduledJob{
+		Cron:      expr,
+		Command:   command,
+		Channel:   in.Channel,
+		User:      in.User,
+		Messenger: m.Name(),
+	}
+
+	id, err := sc.store.SaveSchedule(job)
+	if err != nil {
+		return 0, err
+	}
+	job.ID = id
+
+	return id, sc.register(job)
+}
+
+func (sc *Scheduler) register(job ScheduledJob) error {
+	entryID, err := sc.cron.AddFunc(job.Cron, func() { sc.run(job) })
+	if err != nil {
+		return err
+	}
+	sc.ids[job.ID] = entryID
+	return nil
+}
+
+// Remove cancels and forgets a scheduled job.
+func (sc *Scheduler) Remove(id uint64) error {
+	if sc.store == nil {
+		return errNoStore
+	}
+
+	entryID, ok := sc.ids[id]
+	if !ok {
+		return fmt.Errorf("no schedule with id %d", id)
+	}
+	sc.cron.Remove(entryID)
+	delete(sc.ids, id)
+	return sc.store.DeleteSchedule(id)
+}
+
+// List returns every persisted schedule.
+func (sc *Scheduler) List() ([]ScheduledJob, error) {
+	if sc.store == nil {
+		return nil, errNoStore
+	}
+	return sc.store.ListSchedules()
+}
+
+// run executes a scheduled job's command through the normal dispatch path,
+// skipping it only if it would itself queue a motion and the servo is
+// already mid-motion, so a long sweep isn't retriggered before it finishes;
+// non-motion commands like `stats`/`angle`/`history` still run regardless.
+func (sc *Scheduler) run(job ScheduledJob) {
+	if sc.pm.StartsMotion(job.Command) && sc.servo.Motion.Busy() {
+		fmt.Printf("Schedule #%d skipped: servo busy\n", job.ID)
+		return
+	}
+
+	m := sc.bot.Get(job.Messenger)
+	if m == nil {
+		fmt.Printf("Schedule #%d skipped: messenger %q not connected\n", job.ID, job.Messenger)
+		return
+	}
+
+	in := servoapi.InboundMessage{
+		Text:    job.Command,
+		User:    job.User,
+		Channel: job.Channel,
+	}
+	sc.pm.Dispatch(m, in, sc.servo)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// scheduleAddPattern matches `schedule add "<cron expr>" <command>`, e.g.
+// `schedule add "*/5 * * * *" full left`.
+var scheduleAddPattern = regexp.MustCompile(`^schedule add "([^"]+)" (.+)$`)
+
+// scheduleRemovePattern matches `schedule remove <id>`.
+var scheduleRemovePattern = regexp.MustCompile(`^schedule remove (\d+)$`)
+
+var scheduleListPattern = regexp.MustCompile(`^schedule list$`)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// schedulerPlugin exposes `schedule add/list/remove` as bot commands, on
+// top of a Scheduler.
+type schedulerPlugin struct {
+	scheduler *Scheduler
+}
+
+func newSchedulerPlugin(sc *Scheduler) *schedulerPlugin {
+	return &schedulerPlugin{scheduler: sc}
+}
+
+func (p *schedulerPlugin) Name() string { return "scheduler" }
+
+func (p *schedulerPlugin) Patterns() []*regexp.Regexp {
+	return []*regexp.Regexp{scheduleAddPattern, scheduleRemovePattern, scheduleListPattern}
+}
+
+func (p *schedulerPlugin) Handle(m servoapi.Messenger, in servoapi.InboundMessage, s *servoapi.Servo) error {
+	text := strings.TrimSpace(strings.ToLower(in.Text))
+
+	switch {
+	case scheduleAddPattern.MatchString(text):
+		match := scheduleAddPattern.FindStringSubmatch(text)
+		id, err := p.scheduler.Add(match[1], match[2], m, in)
+		if err != nil {
+			return s.Reply(fmt.Sprintf("Couldn't add schedule: %s", err.Error()), m, in)
+		}
+		return s.Reply(fmt.Sprintf("Scheduled #%d: `%s` on `%s`", id, match[2], match[1]), m, in)
+
+	case scheduleRemovePattern.MatchString(text):
+		match := scheduleRemovePattern.FindStringSubmatch(text)
+		id, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := p.scheduler.Remove(id); err != nil {
+			return s.Reply(fmt.Sprintf("Couldn't remove schedule #%d: %s", id, err.Error()), m, in)
+		}
+		return s.Reply(fmt.Sprintf("Removed schedule #%d", id), m, in)
+
+	case scheduleListPattern.MatchString(text):
+		jobs, err := p.scheduler.List()
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return s.Reply("No schedules set.", m, in)
+		}
+		lines := make([]string, len(jobs))
+		for i, j := range jobs {
+			lines[i] = fmt.Sprintf("#%d: `%s` runs `%s`", j.ID, j.Cron, j.Command)
+		}
+		return s.Reply(strings.Join(lines, "\n"), m, in)
+	}
+
+	return nil
+}
+
+func (p *schedulerPlugin) Help() string {
+	return "`schedule add \"<cron expr>\" <command>` registers a recurring action (e.g. `schedule add \"*/5 * * * *\" full left`), `schedule list` shows them, and `schedule remove <id>` cancels one."
+}
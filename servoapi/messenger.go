@@ -0,0 +1,107 @@
+// Package servoapi is the surface the bot exposes to out-of-tree plugins:
+// the Plugin interface they implement, the Servo they drive, and the
+// backend-agnostic Messenger/InboundMessage types a Handle call is given.
+// It has no dependency on any particular chat backend or persistence
+// engine, so a `.so` plugin can import it without pulling in the bot's
+// internals, and without importing package main, which Go's plugin loader
+// cannot do.
+package servoapi
+
+import "fmt"
+
+////////////////////////////////////////////////////////////////////////////////
+
+// InboundMessage is a single incoming command, normalized across Messenger
+// backends so the dispatcher and every Plugin can stay backend-agnostic.
+type InboundMessage struct {
+	Text      string
+	User      string
+	Channel   string
+	Timestamp string
+
+	// Via is the backend the message arrived on; set by Bot.AddMessenger so
+	// a reply can be routed back to the right one.
+	Via Messenger
+}
+
+// Messenger is implemented by each chat backend the bot can listen and
+// reply on (Slack, Telegram, a local CLI, ...). Several may run at once via
+// Bot.AddMessenger; the servo replies on whichever backend addressed it.
+type Messenger interface {
+	// Name identifies the backend, e.g. in logs.
+	Name() string
+
+	// Connect establishes the backend's connection and starts forwarding
+	// messages to Events(). It must not block.
+	Connect() error
+
+	// Events returns the channel of inbound messages from this backend.
+	Events() <-chan InboundMessage
+
+	// Errors returns a channel of fatal backend errors, e.g. invalid
+	// credentials, that leave this backend unable to keep running.
+	// Backends that have no such failure mode may return nil; receiving
+	// from a nil channel simply never fires.
+	Errors() <-chan error
+
+	// Reply sends text back to wherever in arrived from.
+	Reply(in InboundMessage, text string) error
+
+	// Close tears down the backend's connection.
+	Close() error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AttachmentField is a single label/value pair shown in a rich reply.
+type AttachmentField struct {
+	Title string
+	Value string
+	Short bool
+}
+
+// Attachment is a backend-agnostic rich-reply payload.
+type Attachment struct {
+	Color     string // e.g. "#36a64f"; interpretation is backend-specific
+	Title     string
+	TitleLink string
+	Fields    []AttachmentField
+}
+
+// ReplyOptions customizes how a reply is delivered, for backends rich
+// enough to support it.
+type ReplyOptions struct {
+	Attachments []Attachment
+	Thread      bool // reply in a thread under the original message
+	Broadcast   bool // also post the threaded reply to the channel at large
+}
+
+// RichMessenger is implemented by backends that support attachments,
+// threaded replies, and reactions beyond the base Messenger's plain Reply.
+type RichMessenger interface {
+	Messenger
+
+	// ReplyWithOptions sends a reply with attachments and/or threading.
+	ReplyWithOptions(in InboundMessage, text string, opts ReplyOptions) error
+
+	// React adds an emoji reaction to in, e.g. to acknowledge a command.
+	React(in InboundMessage, emoji string) error
+}
+
+// React adds a checkmark or question-mark reaction to in on backends that
+// support it, so a recognized command is acknowledged without more chat
+// noise.
+func React(m Messenger, in InboundMessage, ok bool) {
+	rich, supported := m.(RichMessenger)
+	if !supported {
+		return
+	}
+
+	emoji := "white_check_mark"
+	if !ok {
+		emoji = "question"
+	}
+	if err := rich.React(in, emoji); err != nil {
+		fmt.Printf("Error: reacting to message: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,188 @@
+package servoapi
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// TurnStepDuration is how long a single `turn left`/`turn right` step
+	// takes to complete; short enough to feel like a discrete nudge.
+	TurnStepDuration = 150 * time.Millisecond
+
+	SlowSweepDuration   = 2000 * time.Millisecond
+	NormalSweepDuration = 1000 * time.Millisecond
+	FastSweepDuration   = 400 * time.Millisecond
+)
+
+// EasingFunc maps normalized progress t (0..1) to an eased progress, also
+// in 0..1.
+type EasingFunc func(t float64) float64
+
+func linearEasing(t float64) float64 { return t }
+
+func easeInOutCubicEasing(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	f := -2*t + 2
+	return 1 - (f*f*f)/2
+}
+
+// springEasing approximates a critically damped spring settling onto 1
+// without overshoot.
+func springEasing(t float64) float64 {
+	return 1 - math.Exp(-6*t)*(1+6*t)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Motion is a single eased move from start to target over duration.
+type Motion struct {
+	start    float32
+	target   float32
+	startAt  time.Time
+	duration time.Duration
+	easing   EasingFunc
+}
+
+func newMotion(start, target float32, duration time.Duration, easing EasingFunc) *Motion {
+	return &Motion{
+		start:    start,
+		target:   target,
+		startAt:  time.Now(),
+		duration: duration,
+		easing:   easing,
+	}
+}
+
+// sample returns the interpolated angle at now, and whether the motion has
+// finished.
+func (mo *Motion) sample(now time.Time) (float32, bool) {
+	if mo.duration <= 0 {
+		return mo.target, true
+	}
+
+	t := float64(now.Sub(mo.startAt)) / float64(mo.duration)
+	if t >= 1 {
+		return mo.target, true
+	}
+	return mo.start + (mo.target-mo.start)*float32(mo.easing(t)), false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MotionScheduler owns the servo's in-flight and queued motions. Ticking it
+// samples whichever motion is current and advances to the next queued one
+// once it completes, so e.g. `sweep 0 1000; sweep 180 1000` runs back to
+// back rather than both starting at once.
+//
+// Its fields are mutated from more than one goroutine (the main tick loop,
+// HTTP handlers, the cron scheduler), so every access goes through mu.
+type MotionScheduler struct {
+	mu sync.Mutex
+
+	easing  EasingFunc
+	speed   time.Duration // duration used for full left/right/center sweeps
+	current *Motion
+	queue   []*Motion
+}
+
+func newMotionScheduler() *MotionScheduler {
+	return &MotionScheduler{
+		easing: easeInOutCubicEasing,
+		speed:  NormalSweepDuration,
+	}
+}
+
+// QueueSweep enqueues a move to target over duration, chaining off the end
+// of whatever is already in flight or queued. currentAngle is used as the
+// start point only when nothing is in flight.
+func (ms *MotionScheduler) QueueSweep(target float32, duration time.Duration, currentAngle float32) {
+	target = clampAngle(target)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	from := currentAngle
+	if ms.current != nil {
+		from = ms.current.target
+		if n := len(ms.queue); n > 0 {
+			from = ms.queue[n-1].target
+		}
+	}
+
+	mo := newMotion(from, target, duration, ms.easing)
+	if ms.current == nil {
+		ms.current = mo
+		return
+	}
+	ms.queue = append(ms.queue, mo)
+}
+
+// Stop cancels any in-flight or queued motion.
+func (ms *MotionScheduler) Stop() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.current = nil
+	ms.queue = nil
+}
+
+// Busy reports whether a motion is currently in flight.
+func (ms *MotionScheduler) Busy() bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.current != nil
+}
+
+// Speed returns the duration used for full left/right/center sweeps.
+func (ms *MotionScheduler) Speed() time.Duration {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.speed
+}
+
+// SetSpeed changes the duration used for full left/right/center sweeps.
+func (ms *MotionScheduler) SetSpeed(d time.Duration) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.speed = d
+}
+
+// Tick advances the scheduler by one step, applying the sampled angle to s.
+// The angle is only persisted once a motion settles, not on every sample,
+// since Tick runs every tick interval for the full length of a sweep.
+func (ms *MotionScheduler) Tick(s *Servo) error {
+	ms.mu.Lock()
+	if ms.current == nil {
+		ms.mu.Unlock()
+		return nil
+	}
+
+	angle, done := ms.current.sample(time.Now())
+	err := s.setAngle(angle)
+	if err == nil && done {
+		if len(ms.queue) > 0 {
+			ms.current, ms.queue = ms.queue[0], ms.queue[1:]
+			ms.current.startAt = time.Now()
+		} else {
+			ms.current = nil
+		}
+	}
+	ms.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if done {
+		return s.PersistAngle()
+	}
+	return nil
+}
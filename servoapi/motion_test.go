@@ -0,0 +1,48 @@
+package servoapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMotionSample(t *testing.T) {
+	start := time.Now()
+	duration := 1000 * time.Millisecond
+
+	cases := []struct {
+		name   string
+		easing EasingFunc
+	}{
+		{"linear", linearEasing},
+		{"easeInOutCubic", easeInOutCubicEasing},
+		{"spring", springEasing},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mo := &Motion{start: 0, target: 180, startAt: start, duration: duration, easing: c.easing}
+
+			if angle, done := mo.sample(start); done || angle != 0 {
+				t.Errorf("at t=0: got angle=%v done=%v, want angle=0 done=false", angle, done)
+			}
+
+			if angle, done := mo.sample(start.Add(duration / 2)); done || angle <= 0 || angle >= 180 {
+				t.Errorf("at t=0.5: got angle=%v done=%v, want 0<angle<180 done=false", angle, done)
+			}
+
+			if angle, done := mo.sample(start.Add(duration)); !done || angle != 180 {
+				t.Errorf("at t=1: got angle=%v done=%v, want angle=180 done=true", angle, done)
+			}
+		})
+	}
+}
+
+// TestMotionSampleZeroDuration covers the zero-duration short-circuit in
+// sample(), which skips easing entirely so a duration of 0 snaps straight to
+// target instead of dividing by zero.
+func TestMotionSampleZeroDuration(t *testing.T) {
+	mo := &Motion{start: 0, target: 90, startAt: time.Now(), duration: 0, easing: linearEasing}
+	if angle, done := mo.sample(time.Now()); !done || angle != 90 {
+		t.Errorf("zero-duration motion: got angle=%v done=%v, want angle=90 done=true", angle, done)
+	}
+}
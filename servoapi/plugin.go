@@ -0,0 +1,35 @@
+package servoapi
+
+import "regexp"
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Plugin is implemented by anything that wants to extend the bot with new
+// commands, either the built-in servo plugin or a `.so` loaded at startup.
+// An out-of-tree plugin implements this against servoapi, not package main,
+// since a `.so` cannot import a main package.
+type Plugin interface {
+	// Name identifies the plugin, e.g. in logs and the `help` output.
+	Name() string
+
+	// Patterns returns the regular expressions this plugin responds to. A
+	// message is routed to Handle if any of them match.
+	Patterns() []*regexp.Regexp
+
+	// Handle services a message that matched one of Patterns().
+	Handle(m Messenger, in InboundMessage, s *Servo) error
+
+	// Help returns a short description of this plugin's commands, shown as
+	// part of the bot's `help` reply.
+	Help() string
+}
+
+// MotionClassifier is implemented by plugins that can tell whether a given
+// command text would start a servo motion, e.g. so the scheduler can skip
+// only those commands while one is already in flight rather than every
+// command.
+type MotionClassifier interface {
+	// StartsMotion reports whether text, a command already matched against
+	// Patterns(), would queue a motion.
+	StartsMotion(text string) bool
+}
@@ -0,0 +1,204 @@
+package servoapi
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	rpio "github.com/sabhiram/go-rpio"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	FreqMultiplier     = 200 // 50hz but in 200 increments to get 10
+	CenterAngleDegrees = 90.0
+	AngleDelta         = 180.0 / (20.0 - 10.0)
+)
+
+func clampAngle(angle float32) float32 {
+	if angle < 0.0 {
+		angle = 0.0
+	} else if angle > 180.0 {
+		angle = 180.0
+	}
+	return angle
+}
+
+// angleColor bands the current angle into a Slack attachment color: green
+// near center, amber and red toward the extremes.
+func angleColor(angle float32) string {
+	switch {
+	case angle < 45 || angle > 135:
+		return "#d00000"
+	case angle < 75 || angle > 105:
+		return "#e8a33d"
+	default:
+		return "#36a64f"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AngleStore persists the servo's last-known angle, so it can be restored
+// instead of recentering on startup, and reports who issued the most recent
+// recognized command for the `angle` command's rich reply. That command need
+// not have moved the servo; `help` or `stats` count too. A nil AngleStore
+// disables both.
+type AngleStore interface {
+	SaveAngle(angle float32) error
+	LoadAngle() (angle float32, found bool, err error)
+	LastMover() (user string, found bool, err error)
+}
+
+// Servo's angle is read and written from several goroutines (the main tick
+// loop, HTTP handlers, the cron scheduler), so every access goes through mu
+// via setAngle/Angle rather than touching the field directly.
+type Servo struct {
+	pin    rpio.Pin
+	mu     sync.Mutex
+	angle  float32
+	Motion *MotionScheduler
+	store  AngleStore
+
+	statusPageURL string // linked from the `angle` command's rich reply title
+}
+
+// NewServo initializes the servo on bcmpid, restoring its last known angle
+// from store instead of centering if one was persisted. store may be nil to
+// disable persistence. statusPageURL, if non-empty, is linked from the
+// `angle` command's rich reply title.
+func NewServo(bcmpid uint8, store AngleStore, statusPageURL string) (*Servo, error) {
+	p := rpio.Pin(bcmpid)
+	p.Mode(rpio.Pwm)
+	p.Freq(50 * FreqMultiplier)
+
+	angle := float32(CenterAngleDegrees)
+	if store != nil {
+		if saved, found, err := store.LoadAngle(); err != nil {
+			return nil, err
+		} else if found {
+			angle = saved
+		}
+	}
+
+	s := &Servo{
+		pin:           p,
+		angle:         angle,
+		Motion:        newMotionScheduler(),
+		store:         store,
+		statusPageURL: statusPageURL,
+	}
+	s.setAngle(angle)
+	if err := s.PersistAngle(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// setAngle sets the servo angle to between 0 and 180 degrees. It does not
+// persist the angle; callers that reach a settled position should follow up
+// with PersistAngle, since this is called on every motion tick and a store
+// write on each one would thrash the disk.
+func (s *Servo) setAngle(angle float32) error {
+	angle = clampAngle(angle)
+
+	// DutyCycle of 1.0ms / 20ms corresponds to 0 deg
+	// 				1.5ms / 20ms corresponds to 90 deg
+	//				2.0ms / 20ms corresponds to 180 deg
+	dc := uint32(((1.0 + (angle / 180.0)) / 20.0) * FreqMultiplier)
+	s.pin.DutyCycle(dc, FreqMultiplier)
+
+	s.mu.Lock()
+	s.angle = angle
+	s.mu.Unlock()
+	return nil
+}
+
+// Angle returns the servo's current angle.
+func (s *Servo) Angle() float32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.angle
+}
+
+// PersistAngle saves the servo's current angle to store, if persistence is
+// enabled. Callers that reach a settled position after cancelling a motion
+// (e.g. the `stop` command) call this explicitly, since MotionScheduler.Tick
+// otherwise only persists once a motion finishes on its own.
+func (s *Servo) PersistAngle() error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.SaveAngle(s.Angle())
+}
+
+// Reply sends msg back to wherever in arrived from.
+func (s *Servo) Reply(msg string, m Messenger, in InboundMessage) error {
+	return m.Reply(in, msg)
+}
+
+// RandomReply sends a randomly chosen acknowledgement back to wherever in
+// arrived from.
+func (s *Servo) RandomReply(m Messenger, in InboundMessage) error {
+	replies := []string{
+		"Umm ok, I can do that for you!",
+		"You must be management, snooping around.",
+		"Looking for waldo? Let me see what I can do.",
+		"Getting right on that boss!",
+	}
+	return s.Reply(replies[rand.Intn(len(replies))], m, in)
+}
+
+// ErrorReply sends a randomly chosen "I didn't understand that" reply back
+// to wherever in arrived from.
+func (s *Servo) ErrorReply(m Messenger, in InboundMessage) error {
+	replies := []string{
+		"Not sure I know what you mean. Type `help` and such.",
+		"You must be looking for the `help`?",
+		"Are you sure that is a valid command?",
+	}
+	return s.Reply(replies[rand.Intn(len(replies))], m, in)
+}
+
+// ReplyAngle answers the `angle` command. On a RichMessenger it replies
+// with an attachment color-coded by how far off-center the servo is,
+// linking to the status page and naming whoever issued the most recent
+// recognized command (of any kind, not only moves) as "Last mover"; plain
+// Messengers just get the angle as text.
+func (s *Servo) ReplyAngle(m Messenger, in InboundMessage) error {
+	angle := s.Angle()
+	text := fmt.Sprintf("Current angle: % .2f°", angle)
+
+	rich, ok := m.(RichMessenger)
+	if !ok {
+		return s.Reply(text, m, in)
+	}
+
+	fields := []AttachmentField{
+		{Title: "Angle", Value: fmt.Sprintf("%.2f°", angle), Short: true},
+	}
+	if mover, found, err := s.lastMover(); err == nil && found {
+		fields = append(fields, AttachmentField{Title: "Last mover", Value: mover, Short: true})
+	}
+
+	return rich.ReplyWithOptions(in, text, ReplyOptions{
+		Thread: true,
+		Attachments: []Attachment{{
+			Color:     angleColor(angle),
+			Title:     "Servo status",
+			TitleLink: s.statusPageURL,
+			Fields:    fields,
+		}},
+	})
+}
+
+// lastMover returns whoever issued the most recent command, per the store's
+// audit log.
+func (s *Servo) lastMover() (user string, found bool, err error) {
+	if s.store == nil {
+		return "", false, nil
+	}
+	return s.store.LastMover()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// servoCmdFunc is the shape of a single servo command handler.
+type servoCmdFunc func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error
+
+// servoCommand pairs a pattern with the handler it triggers. motion marks
+// whether fn queues a motion, so StartsMotion can tell the scheduler which
+// commands are unsafe to re-trigger while one is already in flight.
+type servoCommand struct {
+	pattern *regexp.Regexp
+	fn      servoCmdFunc
+	motion  bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// servoPlugin is the bot's built-in plugin. It implements the original fixed
+// set of servo commands (turn/center/angle/help) as a Plugin so it is
+// dispatched identically to any `.so` plugin loaded at startup.
+type servoPlugin struct {
+	manager  *PluginManager // used to render `help` across all loaded plugins
+	commands []servoCommand
+}
+
+// sweepPattern matches `sweep <deg> <ms>`, e.g. `sweep 180 1000`.
+var sweepPattern = regexp.MustCompile(`^sweep (-?\d+(?:\.\d+)?) (\d+)$`)
+
+// speedPattern matches `speed slow|normal|fast`.
+var speedPattern = regexp.MustCompile(`^speed (slow|normal|fast)$`)
+
+func newServoPlugin(pm *PluginManager) *servoPlugin {
+	p := &servoPlugin{manager: pm}
+	p.commands = []servoCommand{
+		{regexp.MustCompile(`turn left`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			angle := s.Angle()
+			s.Motion.QueueSweep(angle-servoapi.AngleDelta, servoapi.TurnStepDuration, angle)
+			return s.RandomReply(m, in)
+		}, true},
+		{regexp.MustCompile(`turn right`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			angle := s.Angle()
+			s.Motion.QueueSweep(angle+servoapi.AngleDelta, servoapi.TurnStepDuration, angle)
+			return s.RandomReply(m, in)
+		}, true},
+		{regexp.MustCompile(`full left`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			s.Motion.QueueSweep(0.0, s.Motion.Speed(), s.Angle())
+			return s.RandomReply(m, in)
+		}, true},
+		{regexp.MustCompile(`center`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			s.Motion.QueueSweep(servoapi.CenterAngleDegrees, s.Motion.Speed(), s.Angle())
+			return s.RandomReply(m, in)
+		}, true},
+		{regexp.MustCompile(`full right`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			s.Motion.QueueSweep(180.0, s.Motion.Speed(), s.Angle())
+			return s.RandomReply(m, in)
+		}, true},
+		{sweepPattern, func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			match := sweepPattern.FindStringSubmatch(strings.TrimSpace(strings.ToLower(in.Text)))
+			if match == nil {
+				return s.ErrorReply(m, in)
+			}
+			deg, err := strconv.ParseFloat(match[1], 32)
+			if err != nil {
+				return err
+			}
+			ms, err := strconv.Atoi(match[2])
+			if err != nil {
+				return err
+			}
+			s.Motion.QueueSweep(float32(deg), time.Duration(ms)*time.Millisecond, s.Angle())
+			return s.RandomReply(m, in)
+		}, true},
+		{speedPattern, func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			match := speedPattern.FindStringSubmatch(strings.TrimSpace(strings.ToLower(in.Text)))
+			if match == nil {
+				return s.ErrorReply(m, in)
+			}
+			switch match[1] {
+			case "slow":
+				s.Motion.SetSpeed(servoapi.SlowSweepDuration)
+			case "normal":
+				s.Motion.SetSpeed(servoapi.NormalSweepDuration)
+			case "fast":
+				s.Motion.SetSpeed(servoapi.FastSweepDuration)
+			}
+			return s.RandomReply(m, in)
+		}, false},
+		{regexp.MustCompile(`^stop$`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			s.Motion.Stop()
+			if err := s.PersistAngle(); err != nil {
+				return err
+			}
+			return s.RandomReply(m, in)
+		}, false},
+		{regexp.MustCompile(`angle`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			return s.ReplyAngle(m, in)
+		}, false},
+		{regexp.MustCompile(`help`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			return s.Reply(p.manager.HelpText(), m, in)
+		}, false},
+		{regexp.MustCompile(`history`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			return s.Reply(p.manager.historyText(), m, in)
+		}, false},
+		{regexp.MustCompile(`stats`), func(s *servoapi.Servo, m servoapi.Messenger, in servoapi.InboundMessage) error {
+			return s.Reply(p.manager.statsText(), m, in)
+		}, false},
+	}
+	return p
+}
+
+func (p *servoPlugin) Name() string { return "servo" }
+
+func (p *servoPlugin) Patterns() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(p.commands))
+	for i, c := range p.commands {
+		patterns[i] = c.pattern
+	}
+	return patterns
+}
+
+// StartsMotion reports whether text matches a command that queues a motion,
+// satisfying MotionClassifier.
+func (p *servoPlugin) StartsMotion(text string) bool {
+	for _, c := range p.commands {
+		if c.motion && c.pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle dispatches in to every matching command. in.Text may contain
+// several `;`-separated commands (e.g. `sweep 0 1000; sweep 180 1000`),
+// each of which is matched and run in order so queued motions chain
+// correctly.
+func (p *servoPlugin) Handle(m servoapi.Messenger, in servoapi.InboundMessage, s *servoapi.Servo) error {
+	for _, segment := range strings.Split(in.Text, ";") {
+		text := strings.TrimSpace(strings.ToLower(segment))
+		if text == "" {
+			continue
+		}
+
+		segIn := in
+		segIn.Text = text
+		for _, c := range p.commands {
+			if c.pattern.MatchString(text) {
+				if err := c.fn(s, m, segIn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *servoPlugin) Help() string {
+	return "I can `turn left`,`turn right`, `center`, or tell you my current `angle`. You can even say things like `full left` or `full right`. `sweep <deg> <ms>` eases to an angle over time (chain several with `;`), `speed slow|normal|fast` changes how quick full moves are, and `stop` cancels whatever's in flight. Ask for `history` or `stats` to see what's been happening."
+}
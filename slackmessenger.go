@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nlopes/slack"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SlackMessenger implements Messenger on top of Slack's RTM API. This is
+// the bot's original, and default, backend.
+type SlackMessenger struct {
+	token  string
+	api    *slack.Client
+	rtm    *slack.RTM
+	events chan servoapi.InboundMessage
+	errs   chan error
+}
+
+func newSlackMessenger(token string) *SlackMessenger {
+	return &SlackMessenger{
+		token:  token,
+		events: make(chan servoapi.InboundMessage),
+		errs:   make(chan error, 1),
+	}
+}
+
+func (m *SlackMessenger) Name() string { return "slack" }
+
+func (m *SlackMessenger) Connect() error {
+	m.api = slack.New(m.token)
+	m.rtm = m.api.NewRTM()
+	go m.rtm.ManageConnection()
+
+	go func() {
+		for msg := range m.rtm.IncomingEvents {
+			switch evtt := msg.Data.(type) {
+			case *slack.MessageEvent:
+				m.events <- servoapi.InboundMessage{
+					Text:      evtt.Text,
+					User:      evtt.User,
+					Channel:   evtt.Channel,
+					Timestamp: evtt.Timestamp,
+				}
+			case *slack.RTMError:
+				fmt.Printf("Error: %s\n", evtt.Error())
+			case *slack.InvalidAuthEvent:
+				fmt.Printf("Bad credentials\n")
+				m.errs <- errors.New("invalid credentials")
+				close(m.events)
+				close(m.errs)
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *SlackMessenger) Events() <-chan servoapi.InboundMessage {
+	return m.events
+}
+
+// Errors reports invalid Slack credentials, the one fatal failure mode the
+// RTM connection surfaces asynchronously after Connect has already
+// returned successfully.
+func (m *SlackMessenger) Errors() <-chan error {
+	return m.errs
+}
+
+// Reply posts text back into in.Channel, threaded under the message that
+// triggered it so busy channels stay readable.
+func (m *SlackMessenger) Reply(in servoapi.InboundMessage, text string) error {
+	_, _, err := m.api.PostMessage(in.Channel,
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionTS(in.Timestamp),
+	)
+	return err
+}
+
+// ReplyWithOptions posts text with attachments and/or broadcast-out-of-
+// thread behavior.
+func (m *SlackMessenger) ReplyWithOptions(in servoapi.InboundMessage, text string, opts servoapi.ReplyOptions) error {
+	msgOpts := []slack.MsgOption{slack.MsgOptionText(text, false)}
+
+	if opts.Thread {
+		msgOpts = append(msgOpts, slack.MsgOptionTS(in.Timestamp))
+		if opts.Broadcast {
+			msgOpts = append(msgOpts, slack.MsgOptionBroadcast())
+		}
+	}
+	if len(opts.Attachments) > 0 {
+		msgOpts = append(msgOpts, slack.MsgOptionAttachments(toSlackAttachments(opts.Attachments)...))
+	}
+
+	_, _, err := m.api.PostMessage(in.Channel, msgOpts...)
+	return err
+}
+
+// React adds an emoji reaction to the message that arrived as in.
+func (m *SlackMessenger) React(in servoapi.InboundMessage, emoji string) error {
+	return m.rtm.AddReaction(emoji, slack.ItemRef{Channel: in.Channel, Timestamp: in.Timestamp})
+}
+
+func (m *SlackMessenger) Close() error {
+	return m.rtm.Disconnect()
+}
+
+// toSlackAttachments converts our backend-agnostic Attachment into the
+// Slack API's own attachment type.
+func toSlackAttachments(atts []servoapi.Attachment) []slack.Attachment {
+	out := make([]slack.Attachment, len(atts))
+	for i, a := range atts {
+		fields := make([]slack.AttachmentField, len(a.Fields))
+		for j, f := range a.Fields {
+			fields[j] = slack.AttachmentField{Title: f.Title, Value: f.Value, Short: f.Short}
+		}
+		out[i] = slack.Attachment{
+			Color:     a.Color,
+			Title:     a.Title,
+			TitleLink: a.TitleLink,
+			Fields:    fields,
+		}
+	}
+	return out
+}
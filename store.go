@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	cBucketState     = "state"
+	cBucketAudit     = "audit"
+	cBucketUsers     = "users"
+	cBucketSchedules = "schedules"
+	cStateAngleKey   = "angle"
+)
+
+// AuditEntry records a single dispatched command for the `history` and
+// `stats` commands.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Channel string    `json:"channel"`
+	Pattern string    `json:"pattern"`
+	Angle   float32   `json:"angle"`
+}
+
+// UserCount is a single row of the `stats` reply.
+type UserCount struct {
+	User  string
+	Count uint64
+}
+
+// ScheduledJob is a recurring command registered via `schedule add`,
+// re-dispatched through the normal command path on every cron tick.
+type ScheduledJob struct {
+	ID        uint64 `json:"id"`
+	Cron      string `json:"cron"`
+	Command   string `json:"command"`
+	Channel   string `json:"channel"`
+	User      string `json:"user"`
+	Messenger string `json:"messenger"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Store persists servo state and an append-only audit log of every
+// dispatched command to a BoltDB file.
+type Store struct {
+	db *bolt.DB
+}
+
+// openStore opens (creating if necessary) the BoltDB file at path and
+// ensures its buckets exist.
+func openStore(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range []string{cBucketState, cBucketAudit, cBucketUsers, cBucketSchedules} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (st *Store) Close() error {
+	return st.db.Close()
+}
+
+// SaveAngle persists the servo's current angle so it can be restored on the
+// next startup instead of re-centering.
+func (st *Store) SaveAngle(angle float32) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cBucketState))
+		return b.Put([]byte(cStateAngleKey), []byte(fmt.Sprintf("%f", angle)))
+	})
+}
+
+// LoadAngle returns the last persisted angle. found is false if nothing has
+// been saved yet.
+func (st *Store) LoadAngle() (angle float32, found bool, err error) {
+	err = st.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cBucketState))
+		v := b.Get([]byte(cStateAngleKey))
+		if v == nil {
+			return nil
+		}
+		found = true
+		_, serr := fmt.Sscanf(string(v), "%f", &angle)
+		return serr
+	})
+	return angle, found, err
+}
+
+// LogAction appends entry to the audit log and bumps entry.User's command
+// count.
+func (st *Store) LogAction(entry AuditEntry) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		ab := tx.Bucket([]byte(cBucketAudit))
+		seq, err := ab.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := ab.Put(itob(seq), data); err != nil {
+			return err
+		}
+
+		ub := tx.Bucket([]byte(cBucketUsers))
+		count := uint64(0)
+		if v := ub.Get([]byte(entry.User)); v != nil {
+			count = btoi(v)
+		}
+		return ub.Put([]byte(entry.User), itob(count+1))
+	})
+}
+
+// History returns up to the last n audit entries, most recent first.
+func (st *Store) History(n int) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := st.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cBucketAudit))
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && len(entries) < n; k, v = c.Prev() {
+			var e AuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// LastMover returns whoever issued the most recently audited command, per
+// the audit log. found is false if nothing has been logged yet.
+func (st *Store) LastMover() (user string, found bool, err error) {
+	entries, err := st.History(1)
+	if err != nil || len(entries) == 0 {
+		return "", false, err
+	}
+	return entries[0].User, true, nil
+}
+
+// Stats returns per-user command counts, sorted most-frequent first.
+func (st *Store) Stats() ([]UserCount, error) {
+	var counts []UserCount
+	err := st.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cBucketUsers))
+		return b.ForEach(func(k, v []byte) error {
+			counts = append(counts, UserCount{User: string(k), Count: btoi(v)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Count > counts[j].Count })
+	return counts, nil
+}
+
+// SaveSchedule persists job, assigning it a new ID if it doesn't have one,
+// and returns the ID it was stored under.
+func (st *Store) SaveSchedule(job ScheduledJob) (uint64, error) {
+	err := st.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(cBucketSchedules))
+		if job.ID == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			job.ID = id
+		}
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(job.ID), data)
+	})
+	return job.ID, err
+}
+
+// DeleteSchedule removes a previously persisted schedule.
+func (st *Store) DeleteSchedule(id uint64) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(cBucketSchedules)).Delete(itob(id))
+	})
+}
+
+// ListSchedules returns every persisted schedule.
+func (st *Store) ListSchedules() ([]ScheduledJob, error) {
+	var jobs []ScheduledJob
+	err := st.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(cBucketSchedules)).ForEach(func(k, v []byte) error {
+			var job ScheduledJob
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	return jobs, err
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func btoi(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
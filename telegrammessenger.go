@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/sabhiram/slackbot-servo/servoapi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// TelegramMessenger implements Messenger on top of the Telegram Bot API.
+type TelegramMessenger struct {
+	token  string
+	bot    *tb.Bot
+	events chan servoapi.InboundMessage
+}
+
+func newTelegramMessenger(token string) *TelegramMessenger {
+	return &TelegramMessenger{
+		token:  token,
+		events: make(chan servoapi.InboundMessage),
+	}
+}
+
+func (m *TelegramMessenger) Name() string { return "telegram" }
+
+func (m *TelegramMessenger) Connect() error {
+	bot, err := tb.NewBot(tb.Settings{Token: m.token})
+	if err != nil {
+		return fmt.Errorf("connecting to telegram: %s", err.Error())
+	}
+	m.bot = bot
+
+	bot.Handle(tb.OnText, func(msg *tb.Message) {
+		m.events <- servoapi.InboundMessage{
+			Text:    msg.Text,
+			User:    strconv.FormatInt(msg.Sender.ID, 10),
+			Channel: strconv.FormatInt(msg.Chat.ID, 10),
+		}
+	})
+
+	go bot.Start()
+	return nil
+}
+
+func (m *TelegramMessenger) Events() <-chan servoapi.InboundMessage {
+	return m.events
+}
+
+// Errors never fires; telebot surfaces connection failures by returning an
+// error from Connect instead of failing asynchronously.
+func (m *TelegramMessenger) Errors() <-chan error {
+	return nil
+}
+
+func (m *TelegramMessenger) Reply(in servoapi.InboundMessage, text string) error {
+	id, err := strconv.ParseInt(in.Channel, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, err = m.bot.Send(&tb.Chat{ID: id}, text)
+	return err
+}
+
+func (m *TelegramMessenger) Close() error {
+	m.bot.Stop()
+	return nil
+}